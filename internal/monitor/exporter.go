@@ -15,43 +15,176 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"contrib.go.opencensus.io/exporter/stackdriver"
-	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+	"cloud.google.com/go/compute/metadata"
 )
 
-var exporter *stackdriver.Exporter
+// ExporterKind names a supported OpenCensus exporter backend.
+type ExporterKind string
 
-// EnableStackdriverExporter starts to collect monitoring metrics and exports
-// them to Stackdriver iff the given interval is positive.
-func EnableStackdriverExporter(interval time.Duration) error {
-	if interval <= 0 {
+const (
+	// ExporterStackdriver exports views to Google Cloud Monitoring.
+	ExporterStackdriver ExporterKind = "stackdriver"
+
+	// ExporterOTLP forwards views to an OpenTelemetry collector (Grafana,
+	// Datadog, Honeycomb, self-hosted, ...) over gRPC.
+	ExporterOTLP ExporterKind = "otlp"
+
+	// ExporterPrometheus serves views over HTTP for scraping.
+	ExporterPrometheus ExporterKind = "prometheus"
+
+	// ExporterNone disables metrics exporting altogether.
+	ExporterNone ExporterKind = "none"
+)
+
+// Config controls how gcsfuse exports OpenCensus metrics.
+type Config struct {
+	// Kind selects the exporter backend. If empty, Enable picks
+	// ExporterStackdriver when running on GCE (or when ForceStackdriver is
+	// set) and falls back to ExporterPrometheus otherwise.
+	Kind ExporterKind
+
+	// ReportingInterval is how often views are reported. Metrics exporting
+	// is disabled altogether when this is non-positive.
+	ReportingInterval time.Duration
+
+	// ForceStackdriver forces use of the Stackdriver exporter even when the
+	// process does not appear to be running on GCE. Only consulted when
+	// Kind is empty.
+	ForceStackdriver bool
+
+	// PrometheusEndpoint is the address (e.g. ":9100") the Prometheus
+	// /metrics HTTP endpoint is served on. Defaults to ":9100" if empty.
+	PrometheusEndpoint string
+
+	// OTLP holds settings for ExporterOTLP.
+	OTLP OTLPConfig
+
+	// ProjectID overrides the GCP project metrics are reported to. Only
+	// consulted by ExporterStackdriver; left empty it is auto-detected from
+	// the GCE/GKE metadata server.
+	ProjectID string
+
+	// ResourceType overrides the auto-detected Stackdriver MonitoredResource
+	// type. Only consulted by ExporterStackdriver.
+	ResourceType string
+
+	// ExtraLabels are attached to every exported metric in addition to the
+	// mount path, bucket name, and gcsfuse version. Only consulted by
+	// ExporterStackdriver.
+	ExtraLabels map[string]string
+
+	// MountPoint is the local path gcsfuse is mounted at, reported as a
+	// metric label. Only consulted by ExporterStackdriver.
+	MountPoint string
+
+	// BucketName is the GCS bucket being mounted, reported as a metric
+	// label. Only consulted by ExporterStackdriver.
+	BucketName string
+
+	// Version is the gcsfuse version, reported as a metric label. Only
+	// consulted by ExporterStackdriver.
+	Version string
+}
+
+// backend is implemented by each exporter kind so Enable/Close can treat
+// them uniformly.
+type backend interface {
+	start() error
+	stop(ctx context.Context)
+}
+
+// registry maps an ExporterKind to the backend that implements it.
+var registry = map[ExporterKind]func(cfg Config) backend{
+	ExporterStackdriver: func(cfg Config) backend { return newStackdriverBackend(cfg) },
+	ExporterOTLP:        func(cfg Config) backend { return newOTLPBackend(cfg) },
+	ExporterPrometheus:  func(cfg Config) backend { return newPrometheusBackend(cfg) },
+	ExporterNone:        func(cfg Config) backend { return noopBackend{} },
+}
+
+var active backend
+
+// Enable starts collecting monitoring metrics and exports them through the
+// backend named by cfg.Kind, auto-detecting Stackdriver vs. Prometheus when
+// Kind is left empty.
+func Enable(ctx context.Context, cfg Config) error {
+	if cfg.ReportingInterval <= 0 {
 		return nil
 	}
 
-	var err error
-	if exporter, err = stackdriver.NewExporter(stackdriver.Options{
-		ReportingInterval: interval,
-		OnError: func(err error) {
-			logger.Infof("Fail to send metric: %v", err)
-		},
-	}); err != nil {
-		return fmt.Errorf("create exporter: %w", err)
+	kind := selectKind(cfg, metadata.OnGCE())
+
+	newBackend, ok := registry[kind]
+	if !ok {
+		return fmt.Errorf("unknown exporter kind: %q", kind)
 	}
-	if err = exporter.StartMetricsExporter(); err != nil {
-		return fmt.Errorf("start exporter: %w", err)
+
+	b := newBackend(cfg)
+	if err := b.start(); err != nil {
+		return err
 	}
+	active = b
 	return nil
 }
 
+// selectKind resolves cfg.Kind to a concrete ExporterKind, auto-detecting
+// Stackdriver vs. Prometheus when cfg.Kind is left empty. onGCE is passed in
+// rather than read from metadata.OnGCE() directly so the decision is
+// testable without a metadata server.
+func selectKind(cfg Config, onGCE bool) ExporterKind {
+	if cfg.Kind != "" {
+		return cfg.Kind
+	}
+	if cfg.ForceStackdriver || onGCE {
+		return ExporterStackdriver
+	}
+	return ExporterPrometheus
+}
+
+// Close stops whichever exporter Enable started, flushing any pending
+// metrics first.
+func Close(ctx context.Context) {
+	if active != nil {
+		active.stop(ctx)
+		active = nil
+	}
+}
+
+type noopBackend struct{}
+
+func (noopBackend) start() error             { return nil }
+func (noopBackend) stop(ctx context.Context) {}
+
+// EnableMetricsExporter is a thin wrapper that calls Enable with
+// context.Background(), for callers that do not pass a context.
+func EnableMetricsExporter(cfg Config) error {
+	return Enable(context.Background(), cfg)
+}
+
+// CloseMetricsExporter is a thin wrapper that calls Close with
+// context.Background(), for callers that do not pass a context.
+func CloseMetricsExporter() {
+	Close(context.Background())
+}
+
+// EnableStackdriverExporter starts to collect monitoring metrics and exports
+// them to Stackdriver iff the given interval is positive.
+//
+// Deprecated: use Enable with Config{Kind: ExporterStackdriver} instead.
+func EnableStackdriverExporter(interval time.Duration) error {
+	return Enable(context.Background(), Config{
+		Kind:              ExporterStackdriver,
+		ReportingInterval: interval,
+	})
+}
+
 // CloseStackdriverExporter ensures all collected metrics are sent to
 // Stackdriver and closes the exporter.
+//
+// Deprecated: use Close instead.
 func CloseStackdriverExporter() {
-	if exporter != nil {
-		exporter.StopMetricsExporter()
-		exporter.Flush()
-	}
-	exporter = nil
+	Close(context.Background())
 }
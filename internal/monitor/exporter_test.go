@@ -0,0 +1,66 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import "testing"
+
+func TestSelectKind(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cfg   Config
+		onGCE bool
+		want  ExporterKind
+	}{
+		{
+			name:  "explicit kind wins even on GCE",
+			cfg:   Config{Kind: ExporterOTLP},
+			onGCE: true,
+			want:  ExporterOTLP,
+		},
+		{
+			name:  "explicit kind wins off GCE",
+			cfg:   Config{Kind: ExporterNone},
+			onGCE: false,
+			want:  ExporterNone,
+		},
+		{
+			name:  "auto-detect picks Stackdriver on GCE",
+			cfg:   Config{},
+			onGCE: true,
+			want:  ExporterStackdriver,
+		},
+		{
+			name:  "auto-detect picks Prometheus off GCE",
+			cfg:   Config{},
+			onGCE: false,
+			want:  ExporterPrometheus,
+		},
+		{
+			name:  "ForceStackdriver wins off GCE",
+			cfg:   Config{ForceStackdriver: true},
+			onGCE: false,
+			want:  ExporterStackdriver,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := selectKind(tc.cfg, tc.onGCE)
+			if got != tc.want {
+				t.Errorf("selectKind(%+v, %v) = %v, want %v", tc.cfg, tc.onGCE, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"contrib.go.opencensus.io/exporter/ocagent"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+)
+
+// OTLPConfig configures the OTLP exporter, which forwards views to a
+// gRPC-based OpenTelemetry collector endpoint.
+type OTLPConfig struct {
+	// Endpoint is the collector's gRPC address, e.g. "otel-collector:4317".
+	Endpoint string
+
+	// Insecure disables TLS when dialing Endpoint. Defaults to false; set
+	// this only for local collectors without a certificate. Takes
+	// precedence over TLS.
+	Insecure bool
+
+	// TLS configures the connection to Endpoint when Insecure is false.
+	// The zero value dials with the system's default CA pool.
+	TLS OTLPTLSConfig
+
+	// Headers are sent with every export request, e.g. for collector auth.
+	Headers map[string]string
+
+	// Compression names the gRPC compressor to use (e.g. "gzip"). Leave
+	// empty to disable compression.
+	Compression string
+}
+
+// OTLPTLSConfig holds the TLS settings used to dial an OTLP collector.
+type OTLPTLSConfig struct {
+	// CACertFile is a PEM file used to verify the collector's certificate.
+	// Leave empty to use the system's default CA pool.
+	CACertFile string
+
+	// ClientCertFile and ClientKeyFile, together, enable mutual TLS by
+	// presenting a client certificate to the collector. Both must be set
+	// to take effect.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// ServerNameOverride overrides the server name used during the
+	// certificate handshake and verification. Leave empty to use
+	// Endpoint's host.
+	ServerNameOverride string
+}
+
+// otlpBackend forwards views to an OpenTelemetry collector over gRPC.
+type otlpBackend struct {
+	cfg      Config
+	exporter *ocagent.Exporter
+}
+
+func newOTLPBackend(cfg Config) *otlpBackend {
+	return &otlpBackend{cfg: cfg}
+}
+
+func (b *otlpBackend) start() error {
+	opts := []ocagent.ExporterOption{
+		ocagent.WithAddress(b.cfg.OTLP.Endpoint),
+	}
+	if b.cfg.OTLP.Insecure {
+		opts = append(opts, ocagent.WithInsecure())
+	} else {
+		creds, err := tlsCredentials(b.cfg.OTLP.TLS)
+		if err != nil {
+			return fmt.Errorf("load otlp TLS credentials: %w", err)
+		}
+		opts = append(opts, ocagent.WithTLSCredentials(creds))
+	}
+	if len(b.cfg.OTLP.Headers) > 0 {
+		opts = append(opts, ocagent.WithHeaders(b.cfg.OTLP.Headers))
+	}
+	if b.cfg.OTLP.Compression != "" {
+		opts = append(opts, ocagent.WithCompressor(b.cfg.OTLP.Compression))
+	}
+
+	var err error
+	if b.exporter, err = ocagent.NewExporter(opts...); err != nil {
+		return fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	view.SetReportingPeriod(b.cfg.ReportingInterval)
+	view.RegisterExporter(b.exporter)
+	trace.RegisterExporter(b.exporter)
+	return nil
+}
+
+// tlsCredentials builds the gRPC transport credentials used to dial the
+// OTLP collector from cfg: a custom CA pool and/or client certificate when
+// configured, falling back to the system default CA pool otherwise.
+func tlsCredentials(cfg OTLPTLSConfig) (credentials.TransportCredentials, error) {
+	tlsConfig := &tls.Config{ServerName: cfg.ServerNameOverride}
+
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertFile != "" && cfg.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert/key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func (b *otlpBackend) stop(ctx context.Context) {
+	if b.exporter == nil {
+		return
+	}
+	view.UnregisterExporter(b.exporter)
+	trace.UnregisterExporter(b.exporter)
+	if err := b.exporter.Stop(); err != nil {
+		logger.Infof("Fail to stop otlp exporter: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats/view"
+
+	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+)
+
+// prometheusBackend serves views over HTTP for scraping.
+type prometheusBackend struct {
+	cfg      Config
+	exporter *prometheus.Exporter
+	server   *http.Server
+}
+
+func newPrometheusBackend(cfg Config) *prometheusBackend {
+	return &prometheusBackend{cfg: cfg}
+}
+
+func (b *prometheusBackend) start() error {
+	addr := b.cfg.PrometheusEndpoint
+	if addr == "" {
+		addr = ":9100"
+	}
+
+	var err error
+	if b.exporter, err = prometheus.NewExporter(prometheus.Options{
+		OnError: func(err error) {
+			logger.Infof("Fail to export metric: %v", err)
+		},
+	}); err != nil {
+		return fmt.Errorf("create prometheus exporter: %w", err)
+	}
+
+	view.RegisterExporter(b.exporter)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", b.exporter)
+	b.server = &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := b.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Infof("Prometheus metrics server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (b *prometheusBackend) stop(ctx context.Context) {
+	if b.exporter != nil {
+		view.UnregisterExporter(b.exporter)
+	}
+	if b.server == nil {
+		return
+	}
+	if err := b.server.Shutdown(ctx); err != nil {
+		logger.Infof("Fail to shut down Prometheus metrics server: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	"contrib.go.opencensus.io/exporter/stackdriver"
+	"contrib.go.opencensus.io/exporter/stackdriver/monitoredresource"
+	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+)
+
+// stackdriverBackend exports views to Google Cloud Monitoring.
+type stackdriverBackend struct {
+	cfg      Config
+	exporter *stackdriver.Exporter
+}
+
+func newStackdriverBackend(cfg Config) *stackdriverBackend {
+	return &stackdriverBackend{cfg: cfg}
+}
+
+func (b *stackdriverBackend) start() error {
+	pruneViews()
+
+	var err error
+	if b.exporter, err = stackdriver.NewExporter(stackdriver.Options{
+		ProjectID:               b.cfg.ProjectID,
+		ReportingInterval:       b.cfg.ReportingInterval,
+		MonitoredResource:       detectMonitoredResource(b.cfg),
+		DefaultMonitoringLabels: defaultMonitoringLabels(b.cfg),
+		OnError: func(err error) {
+			logger.Infof("Fail to send metric: %v", err)
+		},
+	}); err != nil {
+		return fmt.Errorf("create exporter: %w", err)
+	}
+	if err = b.exporter.StartMetricsExporter(); err != nil {
+		return fmt.Errorf("start exporter: %w", err)
+	}
+	return nil
+}
+
+func (b *stackdriverBackend) stop(ctx context.Context) {
+	if b.exporter == nil {
+		return
+	}
+	b.exporter.StopMetricsExporter()
+	b.exporter.Flush()
+}
+
+// detectMonitoredResource picks the MonitoredResource describing where this
+// process is running, so metrics can be attributed to the right GCE VM, GKE
+// pod, Cloud Run service, or GAE app in Cloud Monitoring. cfg.ResourceType,
+// when set, skips detection and is passed straight through as a generic
+// resource. When the environment looks like Cloud Run or GAE but one of
+// the resource type's required labels can't be resolved, detection falls
+// back to nil (the Stackdriver exporter's "global" default, which only
+// needs a project ID) rather than emit a resource Cloud Monitoring will
+// reject for missing required labels.
+func detectMonitoredResource(cfg Config) monitoredresource.Interface {
+	if cfg.ResourceType != "" {
+		return &genericResource{resType: cfg.ResourceType}
+	}
+
+	if os.Getenv("K_SERVICE") != "" {
+		return cloudRunResource()
+	}
+
+	if os.Getenv("GAE_SERVICE") != "" {
+		return gaeResource()
+	}
+
+	if metadata.OnGCE() {
+		if mr := monitoredresource.Autodetect(); mr != nil {
+			return mr
+		}
+	}
+
+	return nil
+}
+
+// cloudRunResource builds a cloud_run_revision resource, which Cloud
+// Monitoring requires to carry project_id, location, service_name,
+// revision_name, and configuration_name. It returns nil if any of those
+// can't be resolved from the metadata server or Cloud Run's env vars.
+func cloudRunResource() monitoredresource.Interface {
+	projectID, err := metadata.ProjectID()
+	if err != nil {
+		logger.Infof("Fail to detect project ID for cloud_run_revision resource: %v", err)
+		return nil
+	}
+
+	region, err := metadata.Get("instance/region")
+	if err != nil {
+		logger.Infof("Fail to detect region for cloud_run_revision resource: %v", err)
+		return nil
+	}
+
+	configuration := os.Getenv("K_CONFIGURATION")
+	service := os.Getenv("K_SERVICE")
+	revision := os.Getenv("K_REVISION")
+	if configuration == "" || service == "" || revision == "" {
+		logger.Infof("Incomplete Cloud Run env vars; falling back to the default resource")
+		return nil
+	}
+
+	return &genericResource{
+		resType: "cloud_run_revision",
+		labels: map[string]string{
+			"project_id":         projectID,
+			"location":           lastPathSegment(region),
+			"service_name":       service,
+			"revision_name":      revision,
+			"configuration_name": configuration,
+		},
+	}
+}
+
+// gaeResource builds a gae_app resource, which Cloud Monitoring requires to
+// carry project_id, module_id, version_id, and zone. It returns nil if any
+// of those can't be resolved from the metadata server or GAE's env vars.
+func gaeResource() monitoredresource.Interface {
+	projectID, err := metadata.ProjectID()
+	if err != nil {
+		logger.Infof("Fail to detect project ID for gae_app resource: %v", err)
+		return nil
+	}
+
+	zone, err := metadata.Zone()
+	if err != nil {
+		logger.Infof("Fail to detect zone for gae_app resource: %v", err)
+		return nil
+	}
+
+	module := os.Getenv("GAE_SERVICE")
+	version := os.Getenv("GAE_VERSION")
+	if module == "" || version == "" {
+		logger.Infof("Incomplete GAE env vars; falling back to the default resource")
+		return nil
+	}
+
+	return &genericResource{
+		resType: "gae_app",
+		labels: map[string]string{
+			"project_id": projectID,
+			"zone":       zone,
+			"module_id":  module,
+			"version_id": version,
+		},
+	}
+}
+
+// lastPathSegment returns the portion of s after its final "/", or s
+// unchanged if it contains none. The metadata server reports region as a
+// full resource path (e.g. "projects/123/regions/us-central1"); Cloud
+// Monitoring's location label wants just "us-central1".
+func lastPathSegment(s string) string {
+	if i := strings.LastIndex(s, "/"); i != -1 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// defaultMonitoringLabels returns the mount path, bucket name, and gcsfuse
+// version as labels attached to every metric, plus any caller-supplied
+// overrides, so gcsfuse processes can be told apart in Cloud Monitoring.
+func defaultMonitoringLabels(cfg Config) *stackdriver.Labels {
+	labels := &stackdriver.Labels{}
+	if cfg.MountPoint != "" {
+		labels.Set("mount_point", cfg.MountPoint, "gcsfuse mount path")
+	}
+	if cfg.BucketName != "" {
+		labels.Set("bucket_name", cfg.BucketName, "GCS bucket being mounted")
+	}
+	if cfg.Version != "" {
+		labels.Set("version", cfg.Version, "gcsfuse version")
+	}
+	for k, v := range cfg.ExtraLabels {
+		labels.Set(k, v, "")
+	}
+	return labels
+}
+
+// genericResource is a monitoredresource.Interface backed by a fixed type
+// and label set, used for resources monitoredresource.Autodetect doesn't
+// cover (Cloud Run, GAE) or for a caller-supplied ResourceType override.
+type genericResource struct {
+	resType string
+	labels  map[string]string
+}
+
+func (r *genericResource) MonitoredResource() (resType string, labels map[string]string) {
+	return r.resType, r.labels
+}
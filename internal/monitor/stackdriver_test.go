@@ -0,0 +1,55 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import "testing"
+
+func TestLastPathSegment(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "metadata server region path",
+			in:   "projects/123/regions/us-central1",
+			want: "us-central1",
+		},
+		{
+			name: "no slash",
+			in:   "us-central1",
+			want: "us-central1",
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: "",
+		},
+		{
+			name: "trailing slash",
+			in:   "projects/123/regions/",
+			want: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := lastPathSegment(tc.in)
+			if got != tc.want {
+				t.Errorf("lastPathSegment(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
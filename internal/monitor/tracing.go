@@ -0,0 +1,106 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+
+	"contrib.go.opencensus.io/exporter/stackdriver"
+	"contrib.go.opencensus.io/exporter/stackdriver/propagation"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+
+	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+)
+
+// TraceConfig controls OpenCensus trace exporting for gcsfuse's underlying
+// GCS RPCs.
+type TraceConfig struct {
+	// ProjectID overrides the GCP project traces are reported to. Left
+	// empty it is auto-detected from the GCE/GKE metadata server, matching
+	// Config.ProjectID.
+	ProjectID string
+
+	// SamplingFraction is the fraction of requests to trace, in [0, 1].
+	// Zero disables tracing.
+	SamplingFraction float64
+}
+
+var traceExporter *stackdriver.Exporter
+
+// previousDefaultTransport remembers http.DefaultTransport as EnableTracing
+// found it, so CloseTracing can restore it.
+var previousDefaultTransport http.RoundTripper
+
+// EnableTracing registers a Stackdriver trace exporter, installs a
+// trace.ProbabilitySampler for cfg.SamplingFraction, and wraps
+// http.DefaultTransport with InstrumentedTransport so gcsfuse's GCS RPCs
+// (which are issued through it) produce spans and propagate the Stackdriver
+// trace header. This lets a single FUSE request be correlated with its
+// underlying GCS API spans in Cloud Trace. It is a no-op when
+// cfg.SamplingFraction is non-positive.
+func EnableTracing(cfg TraceConfig) error {
+	if cfg.SamplingFraction <= 0 {
+		return nil
+	}
+
+	var err error
+	if traceExporter, err = stackdriver.NewExporter(stackdriver.Options{
+		ProjectID: cfg.ProjectID,
+		OnError: func(err error) {
+			logger.Infof("Fail to send trace: %v", err)
+		},
+	}); err != nil {
+		return fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	trace.RegisterExporter(traceExporter)
+	trace.ApplyConfig(trace.Config{
+		DefaultSampler: trace.ProbabilitySampler(cfg.SamplingFraction),
+	})
+
+	previousDefaultTransport = http.DefaultTransport
+	http.DefaultTransport = InstrumentedTransport(previousDefaultTransport)
+	return nil
+}
+
+// CloseTracing flushes any pending spans, unregisters the trace exporter
+// EnableTracing installed, and restores http.DefaultTransport. Call this on
+// unmount.
+func CloseTracing() {
+	if previousDefaultTransport != nil {
+		http.DefaultTransport = previousDefaultTransport
+		previousDefaultTransport = nil
+	}
+
+	if traceExporter == nil {
+		return
+	}
+	trace.UnregisterExporter(traceExporter)
+	traceExporter.Flush()
+	traceExporter = nil
+}
+
+// InstrumentedTransport wraps base with an ochttp.Transport that records a
+// span for every GCS RPC and propagates the span context using the
+// Stackdriver (X-Cloud-Trace-Context) format, so spans survive the hop to
+// GCS's servers.
+func InstrumentedTransport(base http.RoundTripper) http.RoundTripper {
+	return &ochttp.Transport{
+		Base:        base,
+		Propagation: &propagation.HTTPFormat{},
+	}
+}
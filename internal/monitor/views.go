@@ -0,0 +1,122 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"sync"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricproducer"
+	"go.opencensus.io/stats/view"
+
+	"github.com/googlecloudplatform/gcsfuse/internal/logger"
+)
+
+// mu guards warnedViews.
+var mu sync.Mutex
+
+// warnedViews remembers which view names we've already logged a skip
+// warning for, so a view that never satisfies Stackdriver doesn't flood
+// Cloud Logging on every reporting interval.
+var warnedViews = map[string]bool{}
+
+// Register registers views the same way view.Register does, except views
+// whose aggregation would translate to an unspecified Stackdriver
+// MetricKind are dropped instead of being registered. Use this instead of
+// calling view.Register directly for any view that may be exported through
+// this package's Stackdriver backend.
+func Register(views ...*view.View) error {
+	keep := make([]*view.View, 0, len(views))
+	for _, v := range views {
+		if hasUnspecifiedAggregation(v) {
+			warnUnspecifiedOnce(v.Name)
+			continue
+		}
+		keep = append(keep, v)
+	}
+
+	if len(keep) == 0 {
+		return nil
+	}
+	return view.Register(keep...)
+}
+
+// pruneViews walks every view currently registered with OpenCensus,
+// regardless of whether it went through Register or a direct view.Register
+// call elsewhere, and unregisters any whose exported metric type has no
+// Stackdriver MetricKind equivalent. It's called before starting a
+// Stackdriver exporter so such a view doesn't make the exporter flood Cloud
+// Logging with metricDescriptor.metricKind errors on every reporting
+// interval.
+func pruneViews() {
+	for _, producer := range metricproducer.GlobalManager().GetAll() {
+		for _, m := range producer.Read() {
+			if !hasUnspecifiedMetricKind(m.Descriptor.Type) {
+				continue
+			}
+			warnUnspecifiedOnce(m.Descriptor.Name)
+			if v := view.Find(m.Descriptor.Name); v != nil {
+				view.Unregister(v)
+			}
+		}
+	}
+}
+
+// hasUnspecifiedAggregation reports whether v's aggregation has no
+// Stackdriver MetricKind equivalent: a distribution without bucket
+// boundaries, a custom aggregation, or no aggregation at all. Used by
+// Register to filter views before they're ever handed to view.Register.
+func hasUnspecifiedAggregation(v *view.View) bool {
+	agg := v.Aggregation
+	if agg == nil {
+		return true
+	}
+
+	switch agg.Type {
+	case view.AggTypeSum, view.AggTypeCount, view.AggTypeLastValue:
+		return false
+	case view.AggTypeDistribution:
+		return len(agg.Buckets) == 0
+	default:
+		return true
+	}
+}
+
+// hasUnspecifiedMetricKind reports whether t has no Stackdriver MetricKind
+// equivalent (e.g. metricdata.TypeSummary, or metricdata.TypeNone). Used by
+// pruneViews, which reads back already-registered views' exported metric
+// type straight from the OpenCensus metric registry rather than relying on
+// how the view was registered.
+func hasUnspecifiedMetricKind(t metricdata.Type) bool {
+	switch t {
+	case metricdata.TypeGaugeInt64, metricdata.TypeGaugeFloat64,
+		metricdata.TypeCumulativeInt64, metricdata.TypeCumulativeFloat64,
+		metricdata.TypeCumulativeDistribution:
+		return false
+	default:
+		return true
+	}
+}
+
+func warnUnspecifiedOnce(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if warnedViews[name] {
+		return
+	}
+	warnedViews[name] = true
+	logger.Infof("Skipping view %q: aggregation has no Stackdriver MetricKind equivalent", name)
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"testing"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats/view"
+)
+
+func TestHasUnspecifiedAggregation(t *testing.T) {
+	testCases := []struct {
+		name string
+		v    *view.View
+		want bool
+	}{
+		{
+			name: "sum",
+			v:    &view.View{Aggregation: view.Sum()},
+			want: false,
+		},
+		{
+			name: "count",
+			v:    &view.View{Aggregation: view.Count()},
+			want: false,
+		},
+		{
+			name: "last value",
+			v:    &view.View{Aggregation: view.LastValue()},
+			want: false,
+		},
+		{
+			name: "distribution with bounds",
+			v:    &view.View{Aggregation: view.Distribution(1, 2, 4)},
+			want: false,
+		},
+		{
+			name: "distribution without bounds",
+			v:    &view.View{Aggregation: view.Distribution()},
+			want: true,
+		},
+		{
+			name: "nil aggregation",
+			v:    &view.View{},
+			want: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := hasUnspecifiedAggregation(tc.v)
+			if got != tc.want {
+				t.Errorf("hasUnspecifiedAggregation(%+v) = %v, want %v", tc.v, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHasUnspecifiedMetricKind(t *testing.T) {
+	testCases := []struct {
+		name string
+		kind metricdata.Type
+		want bool
+	}{
+		{name: "gauge int64", kind: metricdata.TypeGaugeInt64, want: false},
+		{name: "gauge float64", kind: metricdata.TypeGaugeFloat64, want: false},
+		{name: "cumulative int64", kind: metricdata.TypeCumulativeInt64, want: false},
+		{name: "cumulative float64", kind: metricdata.TypeCumulativeFloat64, want: false},
+		{name: "cumulative distribution", kind: metricdata.TypeCumulativeDistribution, want: false},
+		{name: "gauge distribution", kind: metricdata.TypeGaugeDistribution, want: true},
+		{name: "summary", kind: metricdata.TypeSummary, want: true},
+		{name: "none", kind: metricdata.TypeNone, want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := hasUnspecifiedMetricKind(tc.kind)
+			if got != tc.want {
+				t.Errorf("hasUnspecifiedMetricKind(%v) = %v, want %v", tc.kind, got, tc.want)
+			}
+		})
+	}
+}